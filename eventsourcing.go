@@ -0,0 +1,75 @@
+package eventsourcing
+
+// AggregateRootID is the id of an aggregate root
+type AggregateRootID string
+
+// Version is the event version of an aggregate root's event stream
+type Version int
+
+// Event holds all the deltas for an aggregate change
+type Event struct {
+	AggregateRootID AggregateRootID
+	Version         Version
+	AggregateType   string
+	Reason          string
+	Data            interface{}
+	// Metadata carries information about an event that isn't part of the domain data itself,
+	// for example a correlation ID, causation ID or the user that triggered it.
+	Metadata map[string]string
+}
+
+// ExpectedVersion expresses what a caller expects the current version of an aggregate's event
+// stream to be when appending new events, so the store can enforce optimistic concurrency against
+// an explicit expectation. The events being saved must still be numbered on from the stored
+// version, whatever the expectation.
+type ExpectedVersion Version
+
+const (
+	// VersionAny skips the expected-version check; events that do not continue on from the stored
+	// version are still rejected
+	VersionAny ExpectedVersion = -1
+	// VersionNoStream expects the aggregate to not yet have any events
+	VersionNoStream ExpectedVersion = -2
+)
+
+// Aggregate is implemented by the aggregates that are built from a history of events
+type Aggregate interface {
+	// Transition applies the state change described by the event to the aggregate
+	Transition(event Event)
+	// Root returns the embedded AggregateRoot so the package can track id and version
+	Root() *AggregateRoot
+}
+
+// AggregateRoot is to be embedded in aggregates so that they get the identity and version
+// bookkeeping needed by the Repository and event store
+type AggregateRoot struct {
+	id            AggregateRootID
+	aggregateType string
+	version       Version
+}
+
+// ID returns the aggregate root id
+func (a *AggregateRoot) ID() AggregateRootID {
+	return a.id
+}
+
+// Version returns the version the aggregate is currently built to
+func (a *AggregateRoot) Version() Version {
+	return a.version
+}
+
+// Root makes AggregateRoot itself satisfy the embedding half of the Aggregate interface
+func (a *AggregateRoot) Root() *AggregateRoot {
+	return a
+}
+
+// BuildFromHistory transitions a through events in order, advancing its id and version as it goes
+func BuildFromHistory(a Aggregate, events []Event) {
+	root := a.Root()
+	for _, event := range events {
+		a.Transition(event)
+		root.id = event.AggregateRootID
+		root.aggregateType = event.AggregateType
+		root.version = event.Version
+	}
+}
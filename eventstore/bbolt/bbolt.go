@@ -7,6 +7,7 @@ import (
 	"github.com/etcd-io/bbolt"
 	"github.com/hallgren/eventsourcing"
 	"github.com/hallgren/eventsourcing/eventstore"
+	"sync"
 	"time"
 )
 
@@ -24,10 +25,80 @@ func itob(v int) []byte {
 	return b
 }
 
+// pointerMagic tags a global order value as a pointer record rather than a legacy duplicated
+// event payload, so old databases can be told apart from new ones during migration.
+const pointerMagic = 0xfe
+
+// encodePointer builds a global order value that points at an event rather than duplicating it:
+// the aggregate's bucket name followed by its local sequence, instead of the event bytes
+// themselves.
+func encodePointer(bucketName string, sequence uint64) []byte {
+	name := []byte(bucketName)
+	b := make([]byte, 1+2+len(name)+8)
+	b[0] = pointerMagic
+	binary.BigEndian.PutUint16(b[1:3], uint16(len(name)))
+	copy(b[3:3+len(name)], name)
+	binary.BigEndian.PutUint64(b[3+len(name):], sequence)
+	return b
+}
+
+// decodePointer parses a pointer record written by encodePointer. ok is false if b is not a
+// pointer record, for example a legacy global order value that still holds a duplicated payload.
+func decodePointer(b []byte) (bucketName string, sequence uint64, ok bool) {
+	if len(b) < 1+2+8 || b[0] != pointerMagic {
+		return "", 0, false
+	}
+	nameLen := int(binary.BigEndian.Uint16(b[1:3]))
+	if len(b) != 1+2+nameLen+8 {
+		return "", 0, false
+	}
+	name := string(b[3 : 3+nameLen])
+	seq := binary.BigEndian.Uint64(b[3+nameLen:])
+	return name, seq, true
+}
+
 // BBolt is a handler for event streaming
 type BBolt struct {
 	db         *bbolt.DB                  // The bbolt db where we store everything
 	serializer eventstore.EventSerializer // The interface that serialize event
+
+	mu            sync.Mutex
+	subscribers   []*subscriber // subscribers fed by publish, guarded by mu
+	lastGlobalSeq int           // global sequence of the last event handed to publish, guarded by mu
+
+	// publishMu serializes publish itself. publish runs after tx.Commit, outside bbolt's
+	// single-writer lock, so two concurrent Save calls can otherwise run publish concurrently over
+	// the same snapshotted subscriber list - racing, for PolicyDisconnect, a send on a subscriber's
+	// channel in one call against the close of that same channel in the other.
+	publishMu sync.Mutex
+}
+
+// BackpressurePolicy controls what publish does for a subscriber whose channel is full
+type BackpressurePolicy int
+
+const (
+	// PolicyDrop discards the event for the slow subscriber rather than blocking Save
+	PolicyDrop BackpressurePolicy = iota
+	// PolicyBlock blocks Save until the slow subscriber has room
+	PolicyBlock
+	// PolicyDisconnect cancels the subscription once its buffer is full
+	PolicyDisconnect
+)
+
+// subscriber is an in-process listener registered against BBolt.subscribers
+type subscriber struct {
+	ch     chan eventstore.GlobalEvent
+	policy BackpressurePolicy
+}
+
+// Subscription is returned by Subscribe. Cancel stops further delivery to its handler.
+type Subscription struct {
+	cancel func()
+}
+
+// Cancel stops the subscription from receiving further events
+func (s *Subscription) Cancel() {
+	s.cancel()
 }
 
 // MustOpenBBolt opens the event stream found in the given file. If the file is not found it will be created and
@@ -56,8 +127,10 @@ func MustOpenBBolt(dbFile string, s eventstore.EventSerializer) *BBolt {
 	}
 }
 
-// Save an aggregate (its events)
-func (e *BBolt) Save(events []eventsourcing.Event) error {
+// Save an aggregate (its events). expectedVersion states what the caller believes the aggregate's
+// current version to be, either eventsourcing.VersionAny, eventsourcing.VersionNoStream or an
+// exact version number, and is enforced against the version already persisted for the aggregate.
+func (e *BBolt) Save(expectedVersion eventsourcing.ExpectedVersion, events []eventsourcing.Event) error {
 	// Return if there is no events to save
 	if len(events) == 0 {
 		return nil
@@ -96,7 +169,7 @@ func (e *BBolt) Save(events []eventsourcing.Event) error {
 	}
 
 	//Validate events
-	err = eventstore.ValidateEvents(aggregateID, currentVersion, events)
+	err = eventstore.ValidateEvents(aggregateID, currentVersion, expectedVersion, events)
 	if err != nil {
 		return err
 	}
@@ -106,6 +179,7 @@ func (e *BBolt) Save(events []eventsourcing.Event) error {
 		return fmt.Errorf("global bucket not found")
 	}
 
+	committed := make([]eventstore.GlobalEvent, 0, len(events))
 	for _, event := range events {
 		sequence, err := evBucket.NextSequence()
 		if err != nil {
@@ -122,24 +196,180 @@ func (e *BBolt) Save(events []eventsourcing.Event) error {
 		}
 		// We need to establish a global event order that spans over all buckets. This is so that we can be
 		// able to play the event (or send) them in the order that they was entered into this database.
-		// The global sequence bucket contains an ordered line of pointer to all events on the form bucket_name:seq_num
+		// The global order bucket holds a pointer record (aggregate bucket name + local sequence) rather
+		// than a second copy of the event, so the two copies can never drift apart.
 		globalSequence, err := globalBucket.NextSequence()
 		if err != nil {
 			return fmt.Errorf("could not get next sequence for global bucket")
 		}
-		err = globalBucket.Put(itob(int(globalSequence)), value)
+		err = globalBucket.Put(itob(int(globalSequence)), encodePointer(bucketName, sequence))
 		if err != nil {
 			return fmt.Errorf("could not save global sequence pointer for %#v", bucketName)
 		}
+		committed = append(committed, eventstore.GlobalEvent{GlobalSequence: int(globalSequence), Event: event})
 	}
 
 	err = tx.Commit()
 	if err != nil {
 		return err
 	}
+
+	// Only fan events out to subscribers once they are durably committed.
+	e.publish(committed)
 	return nil
 }
 
+// Listen registers a feed of every event committed by Save from the point of registration
+// onward. The returned cancel func must be called once the caller is done listening so its
+// channel can be unregistered. If the caller falls behind, events are dropped for it rather than
+// blocking Save.
+func (e *BBolt) Listen() (<-chan eventstore.GlobalEvent, func()) {
+	sub := &subscriber{ch: make(chan eventstore.GlobalEvent, 64), policy: PolicyDrop}
+
+	e.mu.Lock()
+	e.subscribers = append(e.subscribers, sub)
+	e.mu.Unlock()
+
+	return sub.ch, func() { e.removeSubscriber(sub) }
+}
+
+// Subscribe first replays events from fromGlobalSeq onward via GlobalGet, then delivers newly
+// committed events to handler as they are written, with no polling and no gap or duplicate at the
+// handoff between the two. policy controls what happens once a subscriber falls behind: see
+// PolicyDrop, PolicyBlock and PolicyDisconnect. Delivery stops, and the returned Subscription is
+// no longer usable, once handler returns an error or Cancel is called.
+func (e *BBolt) Subscribe(fromGlobalSeq int, policy BackpressurePolicy, handler func(eventsourcing.Event) error) (*Subscription, error) {
+	sub := &subscriber{ch: make(chan eventstore.GlobalEvent, 256), policy: policy}
+
+	// Registering the subscriber before reading the global order's committed sequence is what
+	// makes the handoff from catch-up to live delivery seamless: any event committed after that
+	// read is published to sub.ch once it commits, and any event up to it is already on disk for
+	// the catch-up below. e.lastGlobalSeq alone is not enough, as it only covers events published
+	// since this BBolt was opened.
+	e.mu.Lock()
+	e.subscribers = append(e.subscribers, sub)
+	lastGlobalSeq := e.lastGlobalSeq
+	e.mu.Unlock()
+	if err := e.db.View(func(tx *bbolt.Tx) error {
+		if committed := int(tx.Bucket([]byte(globalEventOrderBucketName)).Sequence()); committed > lastGlobalSeq {
+			lastGlobalSeq = committed
+		}
+		return nil
+	}); err != nil {
+		e.removeSubscriber(sub)
+		return nil, err
+	}
+	if fromGlobalSeq < 1 {
+		fromGlobalSeq = 1 // global sequences start at 1
+	}
+
+	done := make(chan struct{})
+	var cancelOnce sync.Once
+	cancel := func() {
+		cancelOnce.Do(func() {
+			e.removeSubscriber(sub)
+			close(done)
+		})
+	}
+
+	if count := lastGlobalSeq - fromGlobalSeq + 1; count > 0 {
+		history, err := e.GlobalGet(fromGlobalSeq, count)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		for _, event := range history {
+			if err := handler(event); err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-sub.ch:
+				if !ok {
+					return
+				}
+				if event.GlobalSequence <= lastGlobalSeq {
+					// already delivered during catch-up
+					continue
+				}
+				if err := handler(event.Event); err != nil {
+					cancel()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return &Subscription{cancel: cancel}, nil
+}
+
+// removeSubscriber unregisters sub so publish no longer delivers to it
+func (e *BBolt) removeSubscriber(sub *subscriber) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, s := range e.subscribers {
+		if s == sub {
+			e.subscribers = append(e.subscribers[:i], e.subscribers[i+1:]...)
+			break
+		}
+	}
+}
+
+// publish fans committed events out to every registered subscriber according to its
+// BackpressurePolicy, and records the global sequence of the last event handed out. It holds
+// publishMu for its entire body - not just the part that touches subscribers/lastGlobalSeq -
+// because Save calls it outside of bbolt's single-writer lock, so two Saves committing
+// concurrently would otherwise run publish concurrently over the same snapshotted subscriber list.
+// Serializing publish end-to-end means a subscriber is removed and its channel closed by at most
+// one publish call, and no later call can still be sending to it when that happens.
+func (e *BBolt) publish(events []eventstore.GlobalEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	e.publishMu.Lock()
+	defer e.publishMu.Unlock()
+
+	e.mu.Lock()
+	subs := make([]*subscriber, len(e.subscribers))
+	copy(subs, e.subscribers)
+	e.lastGlobalSeq = events[len(events)-1].GlobalSequence
+	e.mu.Unlock()
+
+nextSubscriber:
+	for _, sub := range subs {
+		for _, event := range events {
+			switch sub.policy {
+			case PolicyBlock:
+				sub.ch <- event
+			case PolicyDisconnect:
+				select {
+				case sub.ch <- event:
+				default:
+					e.removeSubscriber(sub)
+					close(sub.ch)
+					// sub.ch is now closed; skip the rest of this batch for it rather than
+					// fall through to another send on the next iteration.
+					continue nextSubscriber
+				}
+			default: // PolicyDrop
+				select {
+				case sub.ch <- event:
+				default:
+					// slow consumer, drop rather than block Save
+				}
+			}
+		}
+	}
+}
+
 // Get aggregate events
 func (e *BBolt) Get(id string, aggregateType string, afterVersion eventsourcing.Version) ([]eventsourcing.Event, error) {
 	bucketName := aggregateKey(aggregateType, id)
@@ -166,24 +396,47 @@ func (e *BBolt) Get(id string, aggregateType string, afterVersion eventsourcing.
 	return events, nil
 }
 
-// GlobalGet returns events from the global order
-func (e *BBolt) GlobalGet(start int, count int) []eventsourcing.Event {
+// GlobalGet returns events from the global order, resolving each pointer record back to the
+// event bytes stored in its aggregate bucket
+func (e *BBolt) GlobalGet(start int, count int) ([]eventsourcing.Event, error) {
 	tx, err := e.db.Begin(false)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	evBucket := tx.Bucket([]byte(globalEventOrderBucketName))
-	cursor := evBucket.Cursor()
+	globalBucket := tx.Bucket([]byte(globalEventOrderBucketName))
+	if globalBucket == nil {
+		return nil, fmt.Errorf("global bucket not found")
+	}
+	cursor := globalBucket.Cursor()
 	events := make([]eventsourcing.Event, 0)
 	counter := 0
 
-	for k, obj := cursor.Seek(itob(start)); k != nil; k, obj = cursor.Next() {
-		event, err := e.serializer.DeserializeEvent(obj)
-		if err != nil {
-			return nil
+	for k, v := cursor.Seek(itob(start)); k != nil; k, v = cursor.Next() {
+		var event eventsourcing.Event
+
+		bucketName, sequence, ok := decodePointer(v)
+		if ok {
+			evBucket := tx.Bucket([]byte(bucketName))
+			if evBucket == nil {
+				return nil, fmt.Errorf("bucket %q referenced by global pointer not found", bucketName)
+			}
+
+			obj := evBucket.Get(itob(int(sequence)))
+			event, err = e.serializer.DeserializeEvent(obj)
+			if err != nil {
+				return nil, fmt.Errorf("could not deserialize event pointed to by global order, %v", err)
+			}
+		} else {
+			// Not a pointer record: a legacy value that hasn't been through
+			// MigrateGlobalOrderToPointers yet and still holds the duplicated payload inline.
+			event, err = e.serializer.DeserializeEvent(v)
+			if err != nil {
+				return nil, fmt.Errorf("could not deserialize legacy global event, %v", err)
+			}
 		}
+
 		events = append(events, event)
 		counter++
 
@@ -191,7 +444,41 @@ func (e *BBolt) GlobalGet(start int, count int) []eventsourcing.Event {
 			break
 		}
 	}
-	return events
+	return events, nil
+}
+
+// MigrateGlobalOrderToPointers upgrades a database whose global order bucket still holds full
+// duplicated event payloads, the layout used before pointer records were introduced, to store
+// pointer records instead. Entries that are already pointer records are left untouched, so it is
+// safe to run more than once.
+func MigrateGlobalOrderToPointers(db *bbolt.DB, s eventstore.EventSerializer) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		globalBucket := tx.Bucket([]byte(globalEventOrderBucketName))
+		if globalBucket == nil {
+			return fmt.Errorf("global bucket not found")
+		}
+
+		cursor := globalBucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if _, _, ok := decodePointer(v); ok {
+				continue
+			}
+
+			event, err := s.DeserializeEvent(v)
+			if err != nil {
+				return fmt.Errorf("could not deserialize legacy global event, %v", err)
+			}
+
+			bucketName := aggregateKey(event.AggregateType, string(event.AggregateRootID))
+			// The local sequence an event was stored under has always tracked its version 1:1,
+			// since every event for an aggregate is appended, in order, to that aggregate's own bucket.
+			pointer := encodePointer(bucketName, uint64(event.Version))
+			if err := globalBucket.Put(k, pointer); err != nil {
+				return fmt.Errorf("could not rewrite global pointer for %#v", bucketName)
+			}
+		}
+		return nil
+	})
 }
 
 // Close closes the event stream and the underlying database
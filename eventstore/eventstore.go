@@ -0,0 +1,67 @@
+package eventstore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hallgren/eventsourcing"
+)
+
+// EventSerializer is implemented by types that can serialize and deserialize events for storage
+type EventSerializer interface {
+	SerializeEvent(event eventsourcing.Event) ([]byte, error)
+	DeserializeEvent(data []byte) (eventsourcing.Event, error)
+}
+
+// ErrConcurrency is returned when the expected version passed to Save does not match the version
+// currently persisted for the aggregate, or when the events being saved do not continue on from
+// that version
+var ErrConcurrency = errors.New("concurrency error")
+
+// GlobalEvent pairs an event with its position in the store's global order, so subscribers and
+// remote consumers can resume from where they left off.
+type GlobalEvent struct {
+	GlobalSequence int
+	Event          eventsourcing.Event
+}
+
+// ValidateEvents checks expectedVersion against currentVersion (the version last persisted for
+// the aggregate) and makes sure events belong to a single aggregate and aggregate type, are in
+// consecutive version order starting at currentVersion+1, and all carry a reason
+func ValidateEvents(aggregateID eventsourcing.AggregateRootID, currentVersion eventsourcing.Version, expectedVersion eventsourcing.ExpectedVersion, events []eventsourcing.Event) error {
+	switch expectedVersion {
+	case eventsourcing.VersionAny:
+		// the caller has no opinion on the current version, skip the expected-version check
+	case eventsourcing.VersionNoStream:
+		if currentVersion != 0 {
+			return ErrConcurrency
+		}
+	default:
+		if eventsourcing.Version(expectedVersion) != currentVersion {
+			return ErrConcurrency
+		}
+	}
+
+	aggregateType := events[0].AggregateType
+
+	for _, event := range events {
+		if event.AggregateRootID != aggregateID {
+			return fmt.Errorf("events holds events for more than one aggregate")
+		}
+
+		if event.AggregateType != aggregateType {
+			return fmt.Errorf("events holds events for more than one aggregate type")
+		}
+
+		if currentVersion+1 != event.Version {
+			return ErrConcurrency
+		}
+
+		if event.Reason == "" {
+			return fmt.Errorf("event holds no reason")
+		}
+
+		currentVersion = event.Version
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package eventsourcing
+
+// EventStore is implemented by the underlying event store a Repository replays aggregates from
+type EventStore interface {
+	Save(expectedVersion ExpectedVersion, events []Event) error
+	Get(id string, aggregateType string, afterVersion Version) ([]Event, error)
+}
+
+// Repository loads and saves aggregates, rebuilding them from a snapshot (when one is configured
+// and available) followed by the events recorded since that snapshot
+type Repository struct {
+	eventStore EventStore
+	snapshot   *Snapshot
+}
+
+// NewRepository creates a Repository. snapshot may be nil if no snapshot store is configured, in
+// which case Get always rebuilds the aggregate from its full event history.
+func NewRepository(eventStore EventStore, snapshot *Snapshot) *Repository {
+	return &Repository{
+		eventStore: eventStore,
+		snapshot:   snapshot,
+	}
+}
+
+// Get rebuilds a from its latest snapshot, if any, and the events that followed it, or from its
+// full event history when no snapshot is available. It returns ErrNotFound if the aggregate has
+// no snapshot and no events.
+func (r *Repository) Get(id AggregateRootID, aggregateType string, a Aggregate) error {
+	afterVersion := Version(0)
+
+	if r.snapshot != nil {
+		err := r.snapshot.Get(aggregateType, id, a)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if err == nil {
+			afterVersion = a.Root().Version()
+		}
+	}
+
+	events, err := r.eventStore.Get(string(id), aggregateType, afterVersion)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 && afterVersion == 0 {
+		return ErrNotFound
+	}
+
+	BuildFromHistory(a, events)
+	return nil
+}
@@ -0,0 +1,46 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// GobCodec marshals event data with encoding/gob
+type GobCodec struct {
+	types map[string]func() interface{}
+}
+
+// NewGobCodec creates an empty GobCodec. Use Register to teach it what concrete type to decode
+// each event type's Data into.
+func NewGobCodec() *GobCodec {
+	return &GobCodec{types: make(map[string]func() interface{})}
+}
+
+// Register tells the codec to decode eventType's Data into a fresh value from newFn, and makes
+// gob aware of that concrete type.
+func (c *GobCodec) Register(eventType string, newFn func() interface{}) {
+	c.types[eventType] = newFn
+	gob.Register(newFn())
+}
+
+// Marshal implements Codec
+func (c *GobCodec) Marshal(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements Codec
+func (c *GobCodec) Unmarshal(data []byte, eventType string) (interface{}, error) {
+	if _, ok := c.types[eventType]; !ok {
+		return nil, fmt.Errorf("no type registered for event type %q", eventType)
+	}
+	var v interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
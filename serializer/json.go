@@ -0,0 +1,40 @@
+package serializer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONCodec marshals event data as JSON
+type JSONCodec struct {
+	types map[string]func() interface{}
+}
+
+// NewJSONCodec creates an empty JSONCodec. Use Register to teach it what concrete type to decode
+// each event type's Data into.
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{types: make(map[string]func() interface{})}
+}
+
+// Register tells the codec to decode eventType's Data into a fresh value from newFn
+func (c *JSONCodec) Register(eventType string, newFn func() interface{}) {
+	c.types[eventType] = newFn
+}
+
+// Marshal implements Codec
+func (c *JSONCodec) Marshal(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+// Unmarshal implements Codec
+func (c *JSONCodec) Unmarshal(data []byte, eventType string) (interface{}, error) {
+	newFn, ok := c.types[eventType]
+	if !ok {
+		return nil, fmt.Errorf("no type registered for event type %q", eventType)
+	}
+	v := newFn()
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
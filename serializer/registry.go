@@ -0,0 +1,219 @@
+// Package serializer implements a pluggable eventstore.EventSerializer that picks a codec per
+// event type, instead of committing an event store to a single wire format.
+package serializer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+
+	"github.com/hallgren/eventsourcing"
+)
+
+// CodecID identifies which Codec was used to encode an event's Data, and is written into the
+// stored value's framing header so it can be read back without any out-of-band configuration.
+type CodecID byte
+
+const (
+	// CodecJSON identifies JSONCodec
+	CodecJSON CodecID = iota + 1
+	// CodecGob identifies GobCodec
+	CodecGob
+	// CodecProtobuf identifies ProtobufCodec
+	CodecProtobuf
+)
+
+// Compression identifies whether, and how, a stored event's payload is compressed
+type Compression byte
+
+const (
+	// CompressionNone stores the codec's output as-is
+	CompressionNone Compression = iota
+	// CompressionGzip gzip-compresses the codec's output
+	CompressionGzip
+)
+
+// frameVersion is bumped only if the framing header's layout itself changes; the codec and
+// compression it describes can grow new values without a version bump, which is what keeps
+// databases written today readable once new codecs are registered.
+const frameVersion byte = 1
+
+// headerLen is version(1) + codec id(1) + compression(1) + event type hash(4) + envelope length(4)
+const headerLen = 1 + 1 + 1 + 4 + 4
+
+// Codec marshals and unmarshals the Data payload of a single event type. The envelope fields
+// (aggregate id, version, reason, metadata) are always framed as JSON by SerializerRegistry
+// itself, so a Codec only ever has to deal with the domain payload.
+type Codec interface {
+	Marshal(data interface{}) ([]byte, error)
+	Unmarshal(data []byte, eventType string) (interface{}, error)
+}
+
+// envelope carries every field of an event except its Data payload
+type envelope struct {
+	AggregateRootID eventsourcing.AggregateRootID
+	Version         eventsourcing.Version
+	AggregateType   string
+	Reason          string
+	Metadata        map[string]string
+}
+
+// SerializerRegistry is an eventstore.EventSerializer that dispatches each event to the Codec
+// registered for its Reason, and frames the codec's output with a small forward-compatible
+// header so a reader can tell which codec, and which compression, wrote a given value.
+type SerializerRegistry struct {
+	codecIDs    map[string]CodecID
+	codecs      map[CodecID]Codec
+	compression Compression
+}
+
+// NewSerializerRegistry creates an empty SerializerRegistry. Use Register to teach it about event
+// types before using it to serialize or deserialize events.
+func NewSerializerRegistry() *SerializerRegistry {
+	return &SerializerRegistry{
+		codecIDs: make(map[string]CodecID),
+		codecs:   make(map[CodecID]Codec),
+	}
+}
+
+// Register tells the registry to encode and decode events whose Reason is eventType with codec,
+// identified by id.
+func (r *SerializerRegistry) Register(eventType string, id CodecID, codec Codec) {
+	r.codecIDs[eventType] = id
+	r.codecs[id] = codec
+}
+
+// WithCompression wraps every codec's output in the given compression before it is written to
+// storage. It returns r so it can be chained onto NewSerializerRegistry.
+func (r *SerializerRegistry) WithCompression(c Compression) *SerializerRegistry {
+	r.compression = c
+	return r
+}
+
+// SerializeEvent implements eventstore.EventSerializer
+func (r *SerializerRegistry) SerializeEvent(event eventsourcing.Event) ([]byte, error) {
+	id, ok := r.codecIDs[event.Reason]
+	if !ok {
+		return nil, fmt.Errorf("no codec registered for event type %q", event.Reason)
+	}
+	codec := r.codecs[id]
+
+	payload, err := codec.Marshal(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal event %q, %v", event.Reason, err)
+	}
+
+	compression := r.compression
+	if compression == CompressionGzip {
+		if payload, err = gzipCompress(payload); err != nil {
+			return nil, fmt.Errorf("could not compress event %q, %v", event.Reason, err)
+		}
+	}
+
+	envelopeBytes, err := json.Marshal(envelope{
+		AggregateRootID: event.AggregateRootID,
+		Version:         event.Version,
+		AggregateType:   event.AggregateType,
+		Reason:          event.Reason,
+		Metadata:        event.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal event envelope, %v", err)
+	}
+
+	header := make([]byte, headerLen)
+	header[0] = frameVersion
+	header[1] = byte(id)
+	header[2] = byte(compression)
+	binary.BigEndian.PutUint32(header[3:7], eventTypeHash(event.Reason))
+	binary.BigEndian.PutUint32(header[7:11], uint32(len(envelopeBytes)))
+
+	out := make([]byte, 0, headerLen+len(envelopeBytes)+len(payload))
+	out = append(out, header...)
+	out = append(out, envelopeBytes...)
+	out = append(out, payload...)
+	return out, nil
+}
+
+// DeserializeEvent implements eventstore.EventSerializer
+func (r *SerializerRegistry) DeserializeEvent(data []byte) (eventsourcing.Event, error) {
+	if len(data) < headerLen {
+		return eventsourcing.Event{}, fmt.Errorf("event data too short to hold a frame header")
+	}
+	if data[0] != frameVersion {
+		return eventsourcing.Event{}, fmt.Errorf("unsupported frame version %d", data[0])
+	}
+	id := CodecID(data[1])
+	compression := Compression(data[2])
+	envelopeLen64 := uint64(binary.BigEndian.Uint32(data[7:11]))
+	if envelopeLen64 > uint64(len(data)-headerLen) {
+		return eventsourcing.Event{}, fmt.Errorf("event data too short to hold its envelope")
+	}
+	envelopeLen := int(envelopeLen64)
+	envelopeBytes := data[headerLen : headerLen+envelopeLen]
+	payload := data[headerLen+envelopeLen:]
+
+	var env envelope
+	if err := json.Unmarshal(envelopeBytes, &env); err != nil {
+		return eventsourcing.Event{}, fmt.Errorf("could not unmarshal event envelope, %v", err)
+	}
+
+	if compression == CompressionGzip {
+		var err error
+		if payload, err = gzipDecompress(payload); err != nil {
+			return eventsourcing.Event{}, fmt.Errorf("could not decompress event %q, %v", env.Reason, err)
+		}
+	}
+
+	codec, ok := r.codecs[id]
+	if !ok {
+		return eventsourcing.Event{}, fmt.Errorf("no codec registered for codec id %d", id)
+	}
+
+	eventData, err := codec.Unmarshal(payload, env.Reason)
+	if err != nil {
+		return eventsourcing.Event{}, fmt.Errorf("could not unmarshal event %q, %v", env.Reason, err)
+	}
+
+	return eventsourcing.Event{
+		AggregateRootID: env.AggregateRootID,
+		Version:         env.Version,
+		AggregateType:   env.AggregateType,
+		Reason:          env.Reason,
+		Metadata:        env.Metadata,
+		Data:            eventData,
+	}, nil
+}
+
+// eventTypeHash is stored in the frame header purely as a diagnostic: it lets a reader notice a
+// codec/event-type mismatch without having to fully decode the envelope first.
+func eventTypeHash(eventType string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(eventType))
+	return h.Sum32()
+}
+
+func gzipCompress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipDecompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,61 @@
+package serializer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hallgren/eventsourcing"
+)
+
+// snapshotEnvelope pairs the serialized aggregate state with the version it was captured at, so
+// Repository.Get knows where to resume replay after restoring the snapshot.
+type snapshotEnvelope struct {
+	Version eventsourcing.Version
+	State   json.RawMessage
+}
+
+// JSONSnapshotSerializer marshals snapshot state as JSON. Like JSONCodec, it needs to be told what
+// concrete type each aggregate type decodes into before it can be used to read snapshots back.
+type JSONSnapshotSerializer struct {
+	types map[string]func() interface{}
+}
+
+// NewJSONSnapshotSerializer creates an empty JSONSnapshotSerializer. Use Register to teach it what
+// concrete type to decode each aggregate type's snapshot state into.
+func NewJSONSnapshotSerializer() *JSONSnapshotSerializer {
+	return &JSONSnapshotSerializer{types: make(map[string]func() interface{})}
+}
+
+// Register tells the serializer to decode aggregateType's snapshot state into a fresh value from
+// newFn
+func (s *JSONSnapshotSerializer) Register(aggregateType string, newFn func() interface{}) {
+	s.types[aggregateType] = newFn
+}
+
+// SerializeSnapshot implements eventsourcing.SnapshotSerializer
+func (s *JSONSnapshotSerializer) SerializeSnapshot(a interface{}, version eventsourcing.Version) ([]byte, error) {
+	state, err := json.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal snapshot state, %v", err)
+	}
+	return json.Marshal(snapshotEnvelope{Version: version, State: state})
+}
+
+// DeserializeSnapshot implements eventsourcing.SnapshotSerializer
+func (s *JSONSnapshotSerializer) DeserializeSnapshot(data []byte, aggregateType string) (interface{}, eventsourcing.Version, error) {
+	var env snapshotEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, 0, fmt.Errorf("could not unmarshal snapshot envelope, %v", err)
+	}
+
+	newFn, ok := s.types[aggregateType]
+	if !ok {
+		return nil, 0, fmt.Errorf("no type registered for aggregate type %q", aggregateType)
+	}
+
+	v := newFn()
+	if err := json.Unmarshal(env.State, v); err != nil {
+		return nil, 0, fmt.Errorf("could not unmarshal snapshot state, %v", err)
+	}
+	return v, env.Version, nil
+}
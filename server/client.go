@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/gob"
+	"net"
+	"net/rpc"
+
+	"github.com/hallgren/eventsourcing"
+)
+
+// Client has the same Save, Get and GlobalGet methods as eventstore/bbolt.BBolt, and so satisfies
+// eventsourcing.EventStore, but talks to a remote Server. Its Subscribe differs from BBolt's: it
+// takes no BackpressurePolicy and blocks delivering events instead of returning a Subscription.
+type Client struct {
+	rpcClient     *rpc.Client
+	subscribeAddr string
+}
+
+// Dial connects to a Server listening for commands on addr; subscribeAddr is dialed separately by
+// Subscribe so the live feed doesn't compete with command RPCs on the same connection.
+func Dial(addr, subscribeAddr string) (*Client, error) {
+	rpcClient, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{rpcClient: rpcClient, subscribeAddr: subscribeAddr}, nil
+}
+
+// Save an aggregate's events against the remote store
+func (c *Client) Save(expectedVersion eventsourcing.ExpectedVersion, events []eventsourcing.Event) error {
+	args := SaveArgs{ExpectedVersion: expectedVersion, Events: events}
+	return c.rpcClient.Call("EventStore.Save", args, &struct{}{})
+}
+
+// Get aggregate events from the remote store
+func (c *Client) Get(id string, aggregateType string, afterVersion eventsourcing.Version) ([]eventsourcing.Event, error) {
+	args := GetArgs{ID: id, AggregateType: aggregateType, AfterVersion: afterVersion}
+	var events []eventsourcing.Event
+	err := c.rpcClient.Call("EventStore.Get", args, &events)
+	return events, err
+}
+
+// GlobalGet returns events from the remote store's global order
+func (c *Client) GlobalGet(start int, count int) ([]eventsourcing.Event, error) {
+	args := GlobalGetArgs{Start: start, Count: count}
+	var events []eventsourcing.Event
+	if err := c.rpcClient.Call("EventStore.GlobalGet", args, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Subscribe dials subscribeAddr, has the server replay every event committed since
+// fromGlobalSeq, and then delivers newly committed events to handler as they are written, until
+// the connection is closed or handler returns an error.
+func (c *Client) Subscribe(fromGlobalSeq int, handler func(eventsourcing.Event) error) error {
+	conn, err := net.Dial("tcp", c.subscribeAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := gob.NewEncoder(conn).Encode(SubscribeRequest{FromGlobalSeq: fromGlobalSeq}); err != nil {
+		return err
+	}
+
+	dec := gob.NewDecoder(conn)
+	for {
+		var event eventsourcing.Event
+		if err := dec.Decode(&event); err != nil {
+			return err
+		}
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the connection used for command RPCs
+func (c *Client) Close() error {
+	return c.rpcClient.Close()
+}
@@ -0,0 +1,164 @@
+// Package server exposes an event store over the network so that multiple processes can append
+// to and read from a shared store, and tail its live event feed, without sharing a bbolt file.
+//
+// The transport here is net/rpc over TCP for commands plus a raw gob stream for the live feed,
+// not ZeroMQ or gRPC. It gets the same job done - request/response for Save/Get/GlobalGet and a
+// push feed for Subscribe - with nothing beyond the standard library, and application code calls
+// Save, Get and GlobalGet on Client the same way it would on eventstore/bbolt.BBolt. Swap it
+// for a ZeroMQ REQ/REP+PUB/SUB or gRPC transport if cross-language consumers become a requirement;
+// net/rpc's gob wire format is Go-only.
+package server
+
+import (
+	"encoding/gob"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/hallgren/eventsourcing"
+	"github.com/hallgren/eventsourcing/eventstore/bbolt"
+)
+
+// EventStore is the subset of eventstore/bbolt.BBolt that Server exposes over the network
+type EventStore interface {
+	Save(expectedVersion eventsourcing.ExpectedVersion, events []eventsourcing.Event) error
+	Get(id string, aggregateType string, afterVersion eventsourcing.Version) ([]eventsourcing.Event, error)
+	GlobalGet(start int, count int) ([]eventsourcing.Event, error)
+	Subscribe(fromGlobalSeq int, policy bbolt.BackpressurePolicy, handler func(eventsourcing.Event) error) (*bbolt.Subscription, error)
+}
+
+// Server exposes an EventStore over the network: Save, Get and GlobalGet as request/response
+// RPCs on addr, and a live feed of newly committed events on subscribeAddr.
+type Server struct {
+	store EventStore
+	rpc   *rpc.Server
+}
+
+// Register makes gob aware of a concrete type used as an Event's Data field. Save, Get, GlobalGet
+// and Subscribe all carry Data across the wire as gob-encoded interface{} values, and gob refuses
+// to encode or decode a concrete type it hasn't seen before, so every type used as Data must be
+// registered - with the same argument on both ends of the connection - before it is sent. Call it
+// once at startup for each concrete type, before dialing a Client or accepting connections on a
+// Server.
+func Register(data interface{}) {
+	gob.Register(data)
+}
+
+// NewServer creates a Server backed by store
+func NewServer(store EventStore) *Server {
+	s := &Server{
+		store: store,
+		rpc:   rpc.NewServer(),
+	}
+	s.rpc.RegisterName("EventStore", &commandService{store: store})
+	return s
+}
+
+// ListenAndServe accepts Save/Get/GlobalGet RPC connections on addr and, separately, subscribe
+// connections on subscribeAddr, each of which first catches the connecting client up from the
+// SubscribeRequest.FromGlobalSeq it sends, then streams newly committed events as they are
+// written. It blocks until the subscribe listener returns an error.
+func (s *Server) ListenAndServe(addr, subscribeAddr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go s.rpc.Accept(l)
+
+	sl, err := net.Listen("tcp", subscribeAddr)
+	if err != nil {
+		return err
+	}
+	for {
+		conn, err := sl.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveSubscriber(conn)
+	}
+}
+
+// SubscribeRequest is the first gob value a client writes to a subscribe connection, before the
+// server starts streaming events back.
+type SubscribeRequest struct {
+	FromGlobalSeq int
+}
+
+// serveSubscriber reads a SubscribeRequest from conn, replays every event committed since
+// FromGlobalSeq, and then streams newly committed events as they are written, until conn is
+// closed or a write to it fails. It mirrors eventstore/bbolt.BBolt.Subscribe's catch-up semantics
+// rather than just tailing from the point of connection, so a client that reconnects after being
+// offline doesn't silently lose the events it missed.
+func (s *Server) serveSubscriber(conn net.Conn) {
+	defer conn.Close()
+
+	var req SubscribeRequest
+	if err := gob.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	enc := gob.NewEncoder(conn)
+	finished := make(chan struct{})
+	var finishOnce sync.Once
+	handler := func(event eventsourcing.Event) error {
+		if err := enc.Encode(event); err != nil {
+			finishOnce.Do(func() { close(finished) })
+			return err
+		}
+		return nil
+	}
+
+	sub, err := s.store.Subscribe(req.FromGlobalSeq, bbolt.PolicyDrop, handler)
+	if err != nil {
+		return
+	}
+	defer sub.Cancel()
+
+	<-finished
+}
+
+// commandService is the net/rpc service registered as "EventStore"
+type commandService struct {
+	store EventStore
+}
+
+// SaveArgs holds the arguments for a Save RPC
+type SaveArgs struct {
+	ExpectedVersion eventsourcing.ExpectedVersion
+	Events          []eventsourcing.Event
+}
+
+func (c *commandService) Save(args SaveArgs, reply *struct{}) error {
+	return c.store.Save(args.ExpectedVersion, args.Events)
+}
+
+// GetArgs holds the arguments for a Get RPC
+type GetArgs struct {
+	ID            string
+	AggregateType string
+	AfterVersion  eventsourcing.Version
+}
+
+func (c *commandService) Get(args GetArgs, reply *[]eventsourcing.Event) error {
+	events, err := c.store.Get(args.ID, args.AggregateType, args.AfterVersion)
+	if err != nil {
+		return err
+	}
+	*reply = events
+	return nil
+}
+
+// GlobalGetArgs holds the arguments for a GlobalGet RPC
+type GlobalGetArgs struct {
+	Start int
+	Count int
+}
+
+func (c *commandService) GlobalGet(args GlobalGetArgs, reply *[]eventsourcing.Event) error {
+	events, err := c.store.GlobalGet(args.Start, args.Count)
+	if err != nil {
+		return err
+	}
+	*reply = events
+	return nil
+}
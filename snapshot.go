@@ -1,8 +1,26 @@
 package eventsourcing
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotFound is returned when no snapshot exists for a given aggregate
+var ErrNotFound = errors.New("not found")
+
+// SnapshotSerializer is implemented by types that can serialize and deserialize snapshot state
+// for storage, mirroring the EventSerializer/Codec split used by the event store: the aggregate
+// type is threaded through DeserializeSnapshot so the serializer can pick the right concrete type
+// to decode into, the same way a Codec picks a type from an event's Reason.
+type SnapshotSerializer interface {
+	SerializeSnapshot(a interface{}, version Version) ([]byte, error)
+	DeserializeSnapshot(data []byte, aggregateType string) (a interface{}, version Version, err error)
+}
+
 type snapshotStore interface {
-	Save(id AggregateRootID, a interface{}) error
-	Get(id AggregateRootID) (interface{}, error)
+	Save(aggregateType string, id AggregateRootID, a interface{}, version Version) error
+	Get(aggregateType string, id AggregateRootID) (interface{}, Version, error)
 }
 
 type Snapshot struct {
@@ -10,14 +28,53 @@ type Snapshot struct {
 }
 
 func NewSnapshot(store snapshotStore) *Snapshot {
-	return &Snapshot{store:store}
+	return &Snapshot{store: store}
 }
 
-func (s *Snapshot) Save(id AggregateRootID, a interface{}) error {
-	return s.store.Save(id, a)
+func (s *Snapshot) Save(aggregateType string, id AggregateRootID, a interface{}, version Version) error {
+	return s.store.Save(aggregateType, id, a, version)
 }
 
-func (s *Snapshot) Get(id AggregateRootID, a interface{}) error {
-	a, err := s.store.Get(id)
-	return err
-}
\ No newline at end of file
+// Get fetches the snapshot for aggregateType/id and copies it into a, which must be a non-nil
+// pointer to the same underlying type that was saved. It also restores a's id, aggregateType and
+// version to what they were when the snapshot was captured, so the caller replays only the events
+// recorded since, and so ID() still works when the snapshot is the only thing a was built from. It
+// returns ErrNotFound when no snapshot exists.
+func (s *Snapshot) Get(aggregateType string, id AggregateRootID, a Aggregate) error {
+	snapshot, version, err := s.store.Get(aggregateType, id)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return ErrNotFound
+	}
+
+	target := reflect.ValueOf(a)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("a must be a non-nil pointer")
+	}
+
+	value := reflect.ValueOf(snapshot)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	// The Set below overwrites the embedded AggregateRoot with whatever it deserialized to, and
+	// its unexported id/aggregateType/version fields are never part of that serialized state, so
+	// they are restored explicitly from the caller's id and aggregateType and the stored version.
+	target.Elem().Set(value)
+	root := a.Root()
+	root.id = id
+	root.aggregateType = aggregateType
+	root.version = version
+	return nil
+}
+
+// SaveSnapshot captures the current state and version of root and persists it through s, keyed by
+// aggregateType. aggregateType must be the same value the caller uses as the event store's
+// AggregateType for root, since that's what Repository.Get looks the snapshot up by - it is not
+// derived from root's Go type, which is free to differ from the domain's aggregate type name.
+func (s *Snapshot) SaveSnapshot(aggregateType string, root Aggregate) error {
+	id := root.Root().ID()
+	version := root.Root().Version()
+	return s.Save(aggregateType, id, root, version)
+}
@@ -0,0 +1,102 @@
+package bbolt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/etcd-io/bbolt"
+	"github.com/hallgren/eventsourcing"
+)
+
+const (
+	snapshotBucketName = "snapshots"
+)
+
+// ErrorNotFound is returned when a given snapshot cannot be found in the store
+var ErrorNotFound = errors.New("NotFoundError")
+
+// BBolt is a snapshot store backed by a bbolt database
+type BBolt struct {
+	db         *bbolt.DB                        // The bbolt db where we store everything
+	serializer eventsourcing.SnapshotSerializer // The interface that serialize the snapshot
+}
+
+// MustOpenBBolt opens the snapshot store found in the given file. If the file is not found it
+// will be created and initialized. Will panic if it has problems persisting the changes to the
+// filesystem.
+func MustOpenBBolt(dbFile string, s eventsourcing.SnapshotSerializer) *BBolt {
+	db, err := bbolt.Open(dbFile, 0600, &bbolt.Options{
+		Timeout: 1 * time.Second,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Ensure that we have a bucket to store the snapshots in
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(snapshotBucketName)); err != nil {
+			return fmt.Errorf("could not create snapshot bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &BBolt{
+		db:         db,
+		serializer: s,
+	}
+}
+
+// Save persists a as the latest snapshot for the given aggregate, at the given version
+func (b *BBolt) Save(aggregateType string, id eventsourcing.AggregateRootID, a interface{}, version eventsourcing.Version) error {
+	value, err := b.serializer.SerializeSnapshot(a, version)
+	if err != nil {
+		return fmt.Errorf("could not serialize snapshot, %v", err)
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		snapshotBucket := tx.Bucket([]byte(snapshotBucketName))
+		if snapshotBucket == nil {
+			return fmt.Errorf("snapshot bucket not found")
+		}
+		return snapshotBucket.Put([]byte(snapshotKey(aggregateType, string(id))), value)
+	})
+}
+
+// Get fetches the latest snapshot for the given aggregate. It returns nil, 0, nil when no
+// snapshot exists so that Snapshot.Get can translate the miss into eventsourcing.ErrNotFound.
+func (b *BBolt) Get(aggregateType string, id eventsourcing.AggregateRootID) (interface{}, eventsourcing.Version, error) {
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	snapshotBucket := tx.Bucket([]byte(snapshotBucketName))
+	if snapshotBucket == nil {
+		return nil, 0, fmt.Errorf("snapshot bucket not found")
+	}
+
+	value := snapshotBucket.Get([]byte(snapshotKey(aggregateType, string(id))))
+	if value == nil {
+		return nil, 0, nil
+	}
+
+	a, version, err := b.serializer.DeserializeSnapshot(value, aggregateType)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not deserialize snapshot, %v", err)
+	}
+	return a, version, nil
+}
+
+// Close closes the underlying database
+func (b *BBolt) Close() error {
+	return b.db.Close()
+}
+
+// snapshotKey generates the key snapshots are stored against from aggregateType and aggregateID
+func snapshotKey(aggregateType, aggregateID string) string {
+	return aggregateType + "_" + aggregateID
+}